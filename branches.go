@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// cherryPickBodyRe matches "Cherry pick of #1234" (any case) in a PR body,
+// the convention used by automated cherry-pick bots.
+var cherryPickBodyRe = regexp.MustCompile(`(?i)cherry[- ]pick of #(\d+)`)
+
+const cherryPickLabel = "cherry-pick"
+
+// mergedPR is a changelog entry de-duplicated across base branches, carrying
+// every branch it was found merged on.
+type mergedPR struct {
+	PR
+	Branches []string
+}
+
+// collectAcrossBranches runs the release-note PR search against each of
+// baseBranches, de-duplicates PRs by number, and folds cherry-picks under
+// their originating PR so patch releases show one entry per change.
+func collectAcrossBranches(repoName string, baseBranches []string) ([]mergedPR, error) {
+	byNumber := map[int]*mergedPR{}
+	var order []int
+
+	for _, b := range baseBranches {
+		queries, err := createQueryString(repoName, b)
+		if err != nil {
+			return nil, err
+		}
+		result, err := gh.SearchIssues(queries, "")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, issue := range result.Issues {
+			number := issue.GetNumber()
+			isCherryPick := false
+			if original, ok := originatingPR(issue); ok {
+				number, isCherryPick = original, true
+			}
+
+			if mp, exists := byNumber[number]; exists {
+				mp.Branches = appendBranch(mp.Branches, b)
+				continue
+			}
+
+			title, link, prType := issue.GetTitle(), issue.GetHTMLURL(), fetchLabel(issue.Labels)
+			if isCherryPick {
+				original, err := gh.GetPullRequest(repoName, number)
+				if err != nil {
+					return nil, fmt.Errorf("fetching originating PR #%d for cherry-pick #%d: %w", number, issue.GetNumber(), err)
+				}
+				title, link, prType = original.GetTitle(), original.GetHTMLURL(), fetchLabelFromPR(original.Labels)
+			}
+
+			byNumber[number] = &mergedPR{
+				PR: PR{
+					Number: number,
+					Title:  title,
+					Link:   link,
+					Type:   prType,
+				},
+				Branches: []string{b},
+			}
+			order = append(order, number)
+		}
+	}
+
+	merged := make([]mergedPR, 0, len(order))
+	for _, n := range order {
+		merged = append(merged, *byNumber[n])
+	}
+	return merged, nil
+}
+
+// originatingPR reports whether issue is a cherry-pick of another PR -- via a
+// "cherry-pick" label or a "Cherry pick of #NNN" body reference -- and
+// returns the original PR number. A cherry-pick label without a parsable
+// "#NNN" body reference has nothing to fold under, so it's left standalone.
+func originatingPR(issue github.Issue) (int, bool) {
+	isCherryPick := cherryPickBodyRe.MatchString(issue.GetBody())
+	for _, l := range issue.Labels {
+		if l.Name != nil && strings.EqualFold(*l.Name, cherryPickLabel) {
+			isCherryPick = true
+		}
+	}
+	if !isCherryPick {
+		return 0, false
+	}
+
+	match := cherryPickBodyRe.FindStringSubmatch(issue.GetBody())
+	if match == nil {
+		return 0, false
+	}
+	original, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, false
+	}
+	return original, true
+}
+
+func appendBranch(branches []string, b string) []string {
+	for _, existing := range branches {
+		if existing == b {
+			return branches
+		}
+	}
+	return append(branches, b)
+}
+
+// buildChangelogModelFromMerged converts de-duplicated, branch-annotated PRs
+// into the structured model non-Markdown Renderers consume.
+func buildChangelogModelFromMerged(repo string, merged []mergedPR) *ChangelogModel {
+	model := &ChangelogModel{
+		Repo:            repo,
+		PreviousRelease: previousRelease,
+		CurrentRelease:  currentRelease,
+	}
+	for _, mp := range merged {
+		model.PRs = append(model.PRs, PRModel{
+			Number:   mp.Number,
+			Title:    mp.Title,
+			URL:      mp.Link,
+			Type:     mp.Type,
+			Branches: mp.Branches,
+		})
+	}
+	return model
+}
+
+// renderMergedPRs formats de-duplicated, branch-annotated PRs into the usual
+// flat Markdown grouping, suffixing entries backported beyond primaryBranch.
+func renderMergedPRs(merged []mergedPR, primaryBranch string) string {
+	sorted := append([]mergedPR(nil), merged...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Type < sorted[j].Type })
+
+	var content strings.Builder
+	var existentLabels []string
+
+	for _, mp := range sorted {
+		if !ContainsString(existentLabels, mp.Type) {
+			content.WriteString("\n## " + strings.Title(mp.Type) + "\n")
+			existentLabels = append(existentLabels, mp.Type)
+		}
+		line := "* " + mp.Title + " - " + mp.Link
+		if suffix := backportSuffix(mp.Branches, primaryBranch); suffix != "" {
+			line += " " + suffix
+		}
+		content.WriteString(line + "\n")
+	}
+
+	return content.String()
+}
+
+// backportSuffix renders "(backported to X, Y)" for every branch a PR landed
+// on besides primaryBranch, or "" if it only landed on primaryBranch.
+func backportSuffix(branchesLandedOn []string, primaryBranch string) string {
+	var others []string
+	for _, b := range branchesLandedOn {
+		if b != primaryBranch {
+			others = append(others, b)
+		}
+	}
+	if len(others) == 0 {
+		return ""
+	}
+	return "(backported to " + strings.Join(others, ", ") + ")"
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/gregjones/httpcache"
+	"github.com/gregjones/httpcache/diskcache"
+	"golang.org/x/oauth2"
+
+	"github.com/shazaum/chrn/gitlog"
+)
+
+const graphqlEndpoint = "https://api.github.com/graphql"
+
+// GithubClient wraps the go-github client with the subset of calls this tool
+// needs to look up releases and fetch/update PRs.
+type GithubClient struct {
+	client     *github.Client
+	httpClient *http.Client
+	org        string
+	token      string
+
+	// Progress, if set, is called after every page fetched by SearchIssues
+	// so long-running searches over large repos can report where they are.
+	Progress func(page, fetchedSoFar int)
+}
+
+// NewGithubClient builds a GithubClient authenticated with the given token,
+// caching responses on disk under cacheDir and backing off on rate limits.
+func NewGithubClient(org, token, cacheDir string) *GithubClient {
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: cachedTransport(cacheDir)})
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	tc := oauth2.NewClient(ctx, ts)
+	return &GithubClient{client: github.NewClient(tc), httpClient: tc, org: org, token: token}
+}
+
+// NewGithubClientNoAuth builds a GithubClient that hits the GitHub API
+// unauthenticated, subject to the much lower anonymous rate limit.
+func NewGithubClientNoAuth(org, cacheDir string) *GithubClient {
+	httpClient := &http.Client{Transport: cachedTransport(cacheDir)}
+	return &GithubClient{client: github.NewClient(httpClient), httpClient: httpClient, org: org}
+}
+
+// cachedTransport builds the shared transport chain: an httpcache-backed
+// disk cache (so 304s don't count against quota) wrapping a rate-limiter
+// that backs off on X-RateLimit-Remaining/Retry-After instead of failing.
+func cachedTransport(cacheDir string) http.RoundTripper {
+	cache := httpcache.NewTransport(diskcache.New(cacheDir))
+	cache.Transport = &rateLimitTransport{base: http.DefaultTransport}
+	return cache
+}
+
+// rateLimitTransport sleeps instead of failing when the GitHub API reports
+// the rate limit is exhausted or asks for a Retry-After backoff.
+type rateLimitTransport struct {
+	base http.RoundTripper
+
+	mu        sync.Mutex
+	remaining int
+	resetAt   time.Time
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	wait := time.Duration(0)
+	if t.remaining == 0 && time.Now().Before(t.resetAt) {
+		wait = time.Until(t.resetAt)
+	}
+	t.mu.Unlock()
+	if wait > 0 {
+		log.Printf("Rate limit exhausted, sleeping %s until reset", wait)
+		time.Sleep(wait)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if retryAfter, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil && retryAfter > 0 {
+		log.Printf("Received Retry-After: %ds, sleeping", retryAfter)
+		time.Sleep(time.Duration(retryAfter) * time.Second)
+		if req.Body == nil || req.GetBody != nil {
+			retry := req.Clone(req.Context())
+			if req.GetBody != nil {
+				if retry.Body, err = req.GetBody(); err != nil {
+					return resp, nil
+				}
+			}
+			resp.Body.Close()
+			return t.RoundTrip(retry)
+		}
+	}
+
+	t.mu.Lock()
+	if remaining, convErr := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); convErr == nil {
+		t.remaining = remaining
+	}
+	if resetUnix, convErr := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); convErr == nil {
+		t.resetAt = time.Unix(resetUnix, 0)
+	}
+	t.mu.Unlock()
+
+	return resp, nil
+}
+
+// GetAPITokenFromFile reads a GitHub API token from path, trimming
+// surrounding whitespace; the whole file content is taken as the token.
+func GetAPITokenFromFile(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// GetLatestRelease returns the tag name of the latest release for repo.
+func (g *GithubClient) GetLatestRelease(repo string) (string, error) {
+	release, _, err := g.client.Repositories.GetLatestRelease(context.Background(), g.org, repo)
+	if err != nil {
+		return "", err
+	}
+	return release.GetTagName(), nil
+}
+
+// GetReleaseTagCreationTime returns the creation time of the commit tagged tag.
+func (g *GithubClient) GetReleaseTagCreationTime(repo, tag string) (createTime time.Time, err error) {
+	release, _, err := g.client.Repositories.GetReleaseByTag(context.Background(), g.org, repo, tag)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return release.GetCreatedAt().Time, nil
+}
+
+// GetDefaultBranch returns the name of repo's default branch (e.g. "main" or
+// "master"), used to auto-detect --branch when it isn't set explicitly.
+func (g *GithubClient) GetDefaultBranch(repo string) (string, error) {
+	r, _, err := g.client.Repositories.Get(context.Background(), g.org, repo)
+	if err != nil {
+		return "", err
+	}
+	return r.GetDefaultBranch(), nil
+}
+
+// GetPullRequest fetches a single PR by number, used to look up the
+// originating PR's title/link when folding a cherry-pick under it.
+func (g *GithubClient) GetPullRequest(repo string, number int) (*github.PullRequest, error) {
+	pr, _, err := g.client.PullRequests.Get(context.Background(), g.org, repo, number)
+	if err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// SearchIssues runs a GitHub issue/PR search over the given query terms, fully
+// paginating through results until exhausted.
+func (g *GithubClient) SearchIssues(queries []string, sortBy string) (*github.IssuesSearchResult, error) {
+	query := strings.Join(queries, " ")
+	opts := &github.SearchOptions{
+		Sort:        sortBy,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	combined := &github.IssuesSearchResult{}
+	for {
+		result, resp, err := g.client.Search.Issues(context.Background(), query, opts)
+		if err != nil {
+			return nil, err
+		}
+		combined.Issues = append(combined.Issues, result.Issues...)
+		combined.Total = result.Total
+		combined.IncompleteResults = result.IncompleteResults
+
+		if g.Progress != nil {
+			g.Progress(resp.PrevPage+1, len(combined.Issues))
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return combined, nil
+}
+
+// UpdateReleaseNotes patches the body of the repo's release tagged tag with content.
+func (g *GithubClient) UpdateReleaseNotes(repo, tag, content string) error {
+	release, _, err := g.client.Repositories.GetReleaseByTag(context.Background(), g.org, repo, tag)
+	if err != nil {
+		return err
+	}
+	release.Body = github.String(content)
+	_, _, err = g.client.Repositories.EditRelease(context.Background(), g.org, repo, release.GetID(), release)
+	return err
+}
+
+// GenerateReleaseNotes asks GitHub to render categorized release notes for
+// tag against prevTag, following the repo's .github/release.yml configuration,
+// and returns the generated body.
+func (g *GithubClient) GenerateReleaseNotes(repo, tag, prevTag, target string) (string, error) {
+	notes, _, err := g.client.Repositories.GenerateReleaseNotes(context.Background(), g.org, repo, &github.GenerateNotesOptions{
+		TagName:         tag,
+		PreviousTagName: &prevTag,
+		TargetCommitish: &target,
+	})
+	if err != nil {
+		if errResp, ok := err.(*github.ErrorResponse); ok {
+			return "", fmt.Errorf("generate-notes request failed (%d): %s", errResp.Response.StatusCode, errResp.Message)
+		}
+		return "", err
+	}
+	return notes.Body, nil
+}
+
+// EnrichPRs batches a single GraphQL query to look up title/author for every
+// PR number in numbers, for use as a gitlog.Enricher. Requires an
+// authenticated client -- the GraphQL API doesn't serve anonymous requests.
+func (g *GithubClient) EnrichPRs(repo string, numbers []int) (map[int]gitlog.PRMeta, error) {
+	if g.token == "" {
+		return nil, fmt.Errorf("enriching PRs via GraphQL requires a --token")
+	}
+
+	var fields strings.Builder
+	for i, n := range numbers {
+		fields.WriteString(fmt.Sprintf(
+			"pr%d: pullRequest(number: %d) { title author { login } }\n", i, n))
+	}
+	query := fmt.Sprintf(`query {
+  repository(owner: %q, name: %q) {
+%s
+  }
+}`, g.org, repo, fields.String())
+
+	body, err := json.Marshal(map[string]string{"query": query})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, graphqlEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "bearer "+g.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("graphql request failed (%d): %s", resp.StatusCode, respBody)
+	}
+
+	var parsed struct {
+		Data map[string]map[string]struct {
+			Title  string `json:"title"`
+			Author struct {
+				Login string `json:"login"`
+			} `json:"author"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+
+	meta := make(map[int]gitlog.PRMeta, len(numbers))
+	repoData := parsed.Data["repository"]
+	for i, n := range numbers {
+		pr, ok := repoData[fmt.Sprintf("pr%d", i)]
+		if !ok {
+			continue
+		}
+		meta[n] = gitlog.PRMeta{Title: pr.Title, Author: pr.Author.Login}
+	}
+	return meta, nil
+}
@@ -0,0 +1,241 @@
+// Package gitlog builds a categorized changelog from a local git history,
+// for repos that don't run a release-note label workflow. Commits are parsed
+// as Conventional Commits and grouped by type; merge/squash commit subjects
+// are mined for the originating PR number so entries can be enriched with
+// the PR's title and author.
+package gitlog
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+var (
+	mergeCommitRe  = regexp.MustCompile(`^Merge pull request #(\d+)`)
+	squashRe       = regexp.MustCompile(`\(#(\d+)\)\s*$`)
+	conventionalRe = regexp.MustCompile(`(?i)^(feat|fix|docs|chore|refactor|perf|test|build|ci)(\([^)]+\))?(!)?:\s*(.+)$`)
+	breakingRe     = regexp.MustCompile(`(?m)^BREAKING CHANGE:\s*(.+)$`)
+)
+
+// category -> heading used in the rendered Markdown, in display order.
+var categoryHeadings = []struct {
+	key     string
+	heading string
+}{
+	{"breaking", "Breaking Changes"},
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"docs", "Documentation"},
+	{"chore", "Chores"},
+	{"other", "Other Changes"},
+}
+
+// categoryHeadingByKey indexes categoryHeadings so parseCommit can fold any
+// conventional-commit type without its own heading (refactor, perf, test,
+// build, ci, ...) into "other" instead of silently dropping it.
+var categoryHeadingByKey = func() map[string]bool {
+	keys := make(map[string]bool, len(categoryHeadings))
+	for _, h := range categoryHeadings {
+		keys[h.key] = true
+	}
+	return keys
+}()
+
+// Entry is a single categorized changelog line, optionally enriched with the
+// originating PR's title and author.
+type Entry struct {
+	Category string
+	Subject  string
+	PRNumber int
+	Title    string
+	Author   string
+}
+
+// Enricher fetches title/author metadata for a batch of PR numbers, e.g. via
+// a single GraphQL call against GitHub.
+type Enricher func(repo string, prNumbers []int) (map[int]PRMeta, error)
+
+// PRMeta is the metadata an Enricher looks up for a PR number.
+type PRMeta struct {
+	Title  string
+	Author string
+}
+
+// Generate opens the repo at gitDir, walks commits between previousRef and
+// currentRef, and returns a categorized Markdown changelog. repo is passed
+// through unchanged to enrich for PR lookups.
+func Generate(gitDir, repo, previousRef, currentRef string, enrich Enricher) (string, error) {
+	entries, err := collectEntries(gitDir, previousRef, currentRef)
+	if err != nil {
+		return "", err
+	}
+
+	if enrich != nil {
+		if err := enrichEntries(repo, entries, enrich); err != nil {
+			return "", err
+		}
+	}
+
+	return render(entries), nil
+}
+
+func collectEntries(gitDir, previousRef, currentRef string) ([]*Entry, error) {
+	repo, err := git.PlainOpen(gitDir)
+	if err != nil {
+		return nil, fmt.Errorf("opening git repo at %s: %w", gitDir, err)
+	}
+
+	from, err := repo.ResolveRevision(plumbing.Revision(previousRef))
+	if err != nil {
+		return nil, fmt.Errorf("resolving previous release %s: %w", previousRef, err)
+	}
+	to, err := repo.ResolveRevision(plumbing.Revision(currentRef))
+	if err != nil {
+		return nil, fmt.Errorf("resolving current release %s: %w", currentRef, err)
+	}
+
+	excluded, err := ancestorSet(repo, *from)
+	if err != nil {
+		return nil, fmt.Errorf("walking ancestors of %s: %w", previousRef, err)
+	}
+
+	commits, err := repo.Log(&git.LogOptions{From: *to})
+	if err != nil {
+		return nil, fmt.Errorf("walking commit log: %w", err)
+	}
+
+	var entries []*Entry
+	err = commits.ForEach(func(c *object.Commit) error {
+		if excluded[c.Hash] {
+			return nil
+		}
+		entries = append(entries, parseCommit(c))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking commit log: %w", err)
+	}
+
+	return entries, nil
+}
+
+// ancestorSet returns the hashes of start and every commit reachable from it,
+// so collectEntries can exclude previousRelease's whole history rather than
+// stopping at the first commit whose hash matches -- a merged-in branch can
+// otherwise carry the walk past previousRelease before reaching it.
+func ancestorSet(repo *git.Repository, start plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	commits, err := repo.Log(&git.LogOptions{From: start})
+	if err != nil {
+		return nil, err
+	}
+
+	set := map[plumbing.Hash]bool{}
+	err = commits.ForEach(func(c *object.Commit) error {
+		set[c.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+func parseCommit(c *object.Commit) *Entry {
+	subject := strings.SplitN(c.Message, "\n", 2)[0]
+	entry := &Entry{Category: "other", Subject: subject, PRNumber: prNumberFromSubject(subject)}
+
+	if breakingRe.MatchString(c.Message) {
+		entry.Category = "breaking"
+		return entry
+	}
+
+	if match := conventionalRe.FindStringSubmatch(subject); match != nil {
+		entry.Category = "other"
+		if _, ok := categoryHeadingByKey[strings.ToLower(match[1])]; ok {
+			entry.Category = strings.ToLower(match[1])
+		}
+		entry.Subject = strings.TrimSpace(match[4])
+		if match[3] == "!" {
+			entry.Category = "breaking"
+		}
+	}
+
+	return entry
+}
+
+func prNumberFromSubject(subject string) int {
+	if match := mergeCommitRe.FindStringSubmatch(subject); match != nil {
+		if n, err := strconv.Atoi(match[1]); err == nil {
+			return n
+		}
+	}
+	if match := squashRe.FindStringSubmatch(subject); match != nil {
+		if n, err := strconv.Atoi(match[1]); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+func enrichEntries(repo string, entries []*Entry, enrich Enricher) error {
+	var numbers []int
+	for _, e := range entries {
+		if e.PRNumber != 0 {
+			numbers = append(numbers, e.PRNumber)
+		}
+	}
+	if len(numbers) == 0 {
+		return nil
+	}
+
+	meta, err := enrich(repo, numbers)
+	if err != nil {
+		return fmt.Errorf("enriching commits with PR metadata: %w", err)
+	}
+	for _, e := range entries {
+		if m, ok := meta[e.PRNumber]; ok {
+			e.Title = m.Title
+			e.Author = m.Author
+		}
+	}
+	return nil
+}
+
+func render(entries []*Entry) string {
+	byCategory := map[string][]*Entry{}
+	for _, e := range entries {
+		byCategory[e.Category] = append(byCategory[e.Category], e)
+	}
+
+	var b strings.Builder
+	for _, h := range categoryHeadings {
+		group := byCategory[h.key]
+		if len(group) == 0 {
+			continue
+		}
+		sort.SliceStable(group, func(i, j int) bool { return group[i].Subject < group[j].Subject })
+
+		b.WriteString(fmt.Sprintf("\n## %s\n", h.heading))
+		for _, e := range group {
+			b.WriteString("* " + e.line() + "\n")
+		}
+	}
+	return b.String()
+}
+
+func (e *Entry) line() string {
+	if e.Title == "" {
+		return e.Subject
+	}
+	if e.Author != "" {
+		return fmt.Sprintf("%s (#%d) @%s", e.Title, e.PRNumber, e.Author)
+	}
+	return fmt.Sprintf("%s (#%d)", e.Title, e.PRNumber)
+}
@@ -0,0 +1,103 @@
+// Package hierarchy renders a SIG -> Area -> Kind -> PR changelog from a set
+// of GitHub issues labelled with sig/*, area/* and kind/* labels.
+package hierarchy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+const (
+	sigPrefix  = "sig/"
+	areaPrefix = "area/"
+	kindPrefix = "kind/"
+
+	uncategorized = "Uncategorized"
+)
+
+// PR is a single changelog entry carried through the hierarchy tree.
+type PR struct {
+	Title string
+	Link  string
+}
+
+// Tree is a SIG -> Area -> Kind -> PR nested grouping.
+type Tree map[string]map[string]map[string][]PR
+
+// Build walks every issue in result, buckets it by its sig/area/kind labels
+// and returns the resulting tree. Issues missing one of the labels fall back
+// to the "Uncategorized" bucket at that level.
+func Build(result *github.IssuesSearchResult) Tree {
+	tree := Tree{}
+
+	for _, issue := range result.Issues {
+		sig, area, kind := uncategorized, uncategorized, uncategorized
+		for _, l := range issue.Labels {
+			if l.Name == nil {
+				continue
+			}
+			switch {
+			case strings.HasPrefix(*l.Name, sigPrefix):
+				sig = strings.TrimPrefix(*l.Name, sigPrefix)
+			case strings.HasPrefix(*l.Name, areaPrefix):
+				area = strings.TrimPrefix(*l.Name, areaPrefix)
+			case strings.HasPrefix(*l.Name, kindPrefix):
+				kind = strings.TrimPrefix(*l.Name, kindPrefix)
+			}
+		}
+
+		if tree[sig] == nil {
+			tree[sig] = map[string]map[string][]PR{}
+		}
+		if tree[sig][area] == nil {
+			tree[sig][area] = map[string][]PR{}
+		}
+		tree[sig][area][kind] = append(tree[sig][area][kind], PR{
+			Title: issue.GetTitle(),
+			Link:  issue.GetHTMLURL(),
+		})
+	}
+
+	return tree
+}
+
+// Render builds a Markdown changelog from tree, with SIGs as "##" headings,
+// areas as "###" and kinds as "####", each level sorted lexicographically.
+func Render(tree Tree) string {
+	var b strings.Builder
+
+	for _, sig := range sortedKeys(tree) {
+		b.WriteString(fmt.Sprintf("## %s\n", sig))
+		areas := tree[sig]
+		for _, area := range sortedKeys(areas) {
+			b.WriteString(fmt.Sprintf("### %s\n", area))
+			kinds := areas[area]
+			for _, kind := range sortedKeys(kinds) {
+				b.WriteString(fmt.Sprintf("#### %s\n", kind))
+				for _, pr := range kinds[kind] {
+					b.WriteString(fmt.Sprintf("* %s - %s\n", pr.Title, pr.Link))
+				}
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// Content builds and renders the hierarchy in a single call, the entry point
+// used by the CLI's --hierarchy mode.
+func Content(result *github.IssuesSearchResult) string {
+	return Render(Build(result))
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
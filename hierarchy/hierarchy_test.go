@@ -0,0 +1,71 @@
+package hierarchy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/github"
+)
+
+func strPtr(s string) *string { return &s }
+
+func label(name string) github.Label {
+	return github.Label{Name: strPtr(name)}
+}
+
+func fixtureIssues() *github.IssuesSearchResult {
+	return &github.IssuesSearchResult{
+		Issues: []github.Issue{
+			{
+				Title:   strPtr("Add retry to scheduler"),
+				URL:     strPtr("https://api.github.com/repos/org/repo/issues/1"),
+				HTMLURL: strPtr("https://github.com/org/repo/pull/1"),
+				Labels:  []github.Label{label("sig/scheduling"), label("area/preemption"), label("kind/bug")},
+			},
+			{
+				Title:   strPtr("Document kubeconfig flags"),
+				URL:     strPtr("https://api.github.com/repos/org/repo/issues/2"),
+				HTMLURL: strPtr("https://github.com/org/repo/pull/2"),
+				Labels:  []github.Label{label("sig/cli"), label("area/auth"), label("kind/documentation")},
+			},
+			{
+				Title:   strPtr("Random cleanup"),
+				URL:     strPtr("https://api.github.com/repos/org/repo/issues/3"),
+				HTMLURL: strPtr("https://github.com/org/repo/pull/3"),
+				Labels:  []github.Label{},
+			},
+		},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	tree := Build(fixtureIssues())
+
+	if _, ok := tree["scheduling"]["preemption"]["bug"]; !ok {
+		t.Fatalf("expected sig/area/kind bucket for scheduling/preemption/bug, got %+v", tree)
+	}
+	if _, ok := tree[uncategorized][uncategorized][uncategorized]; !ok {
+		t.Fatalf("expected fully uncategorized bucket for unlabelled issue, got %+v", tree)
+	}
+}
+
+func TestRender(t *testing.T) {
+	content := Content(fixtureIssues())
+
+	for _, want := range []string{
+		"## cli\n",
+		"### auth\n",
+		"#### documentation\n",
+		"* Document kubeconfig flags - https://github.com/org/repo/pull/2\n",
+		"* Add retry to scheduler - https://github.com/org/repo/pull/1\n",
+		"## " + uncategorized,
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected rendered content to contain %q, got:\n%s", want, content)
+		}
+	}
+
+	if strings.Contains(content, "api.github.com") {
+		t.Errorf("expected rendered content to use HTML URLs, not the API URL, got:\n%s", content)
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"github.com/spf13/cobra"
 	"log"
 	"os"
-	"sort"
 	"strings"
 	"time"
+
+	"github.com/shazaum/chrn/gitlog"
+	"github.com/shazaum/chrn/hierarchy"
 )
 
 var (
@@ -20,6 +22,15 @@ var (
 	token           string
 	previousRelease string
 	currentRelease  string
+	useHierarchy    bool
+	backend         string
+	branch          string
+	branches        []string
+	source          string
+	gitDir          string
+	cacheDir        string
+	format          string
+	template        string
 
 	gh *GithubClient
 
@@ -32,16 +43,21 @@ var (
 Changelog generator for GITHUB releases automatically.
 `,
 		PreRun: func(cmd *cobra.Command, args []string) {
+			if cacheDir == "" {
+				cacheDir = defaultCacheDir()
+			}
 			if token != "" {
 				tok, err := GetAPITokenFromFile(token)
 				if err != nil {
 					log.Fatalf("Error accessing user supplied token_file: %v\n", err)
 				}
-				gh = NewGithubClient(org, tok)
+				gh = NewGithubClient(org, tok, cacheDir)
 			} else {
-				gh = NewGithubClientNoAuth(org)
+				gh = NewGithubClientNoAuth(org, cacheDir)
+			}
+			gh.Progress = func(page, fetchedSoFar int) {
+				log.Printf("Fetched page %d (%d issues so far)", page, fetchedSoFar)
 			}
-
 		},
 		Run: func(cmd *cobra.Command, args []string) {
 
@@ -57,19 +73,20 @@ Changelog generator for GITHUB releases automatically.
 			}()
 
 			log.Printf("Start fetching release note from %s/%s", org, repo)
-			queries, err := createQueryString(repo)
-			if err != nil {
-				log.Printf("Failed to create query string for %s", repo)
-				return
-			}
 
-			log.Printf("Query: %v", queries)
-			issuesResult, err := gh.SearchIssues(queries, "")
+			var content string
+			switch {
+			case source == sourceGit:
+				content, err = generateFromGit(repo)
+			case backend == backendGithubGenerated:
+				content, err = generateFromGithub(repo)
+			default:
+				content, err = generateFromSearch(repo)
+			}
 			if err != nil {
-				log.Printf("Failed to fetch PR with release note for %s: %s", repo, err)
+				log.Printf("Failed to generate release notes for %s: %s", repo, err)
 				return
 			}
-			content := groupedLabelContent(issuesResult)
 
 			log.Printf("Saving data on: %v", outputFile)
 			f.WriteString(content)
@@ -84,6 +101,136 @@ Changelog generator for GITHUB releases automatically.
 	}
 )
 
+const (
+	// backendSearch is the default backend: run the release-note labelled
+	// PR search and group the results ourselves.
+	backendSearch = "search"
+	// backendGithubGenerated delegates categorization to GitHub's own
+	// generate-notes endpoint, driven by .github/release.yml.
+	backendGithubGenerated = "github-generated"
+
+	// sourceSearch is the default source: query GitHub for release-note
+	// labelled PRs (or the github-generated backend above).
+	sourceSearch = "search"
+	// sourceGit builds the changelog from a local git history instead,
+	// for repos that don't run a release-note label workflow.
+	sourceGit = "git"
+)
+
+// generateFromSearch builds release notes from the release-note labelled PR
+// search, honoring --hierarchy for the output shape and --branches for a
+// combined multi-branch changelog.
+func generateFromSearch(repo string) (string, error) {
+	if len(branches) > 0 {
+		if useHierarchy {
+			return "", fmt.Errorf("--hierarchy is not supported together with --branches")
+		}
+
+		merged, err := collectAcrossBranches(repo, branches)
+		if err != nil {
+			return "", err
+		}
+
+		renderer, err := RendererFor(format, template)
+		if err != nil {
+			return "", err
+		}
+		if _, ok := renderer.(MarkdownRenderer); ok {
+			return renderMergedPRs(merged, branches[0]), nil
+		}
+		rendered, err := renderer.Render(buildChangelogModelFromMerged(repo, merged))
+		if err != nil {
+			return "", err
+		}
+		return string(rendered), nil
+	}
+
+	queries, err := createQueryString(repo, branch)
+	if err != nil {
+		log.Printf("Failed to create query string for %s", repo)
+		return "", err
+	}
+
+	log.Printf("Query: %v", queries)
+	issuesResult, err := gh.SearchIssues(queries, "")
+	if err != nil {
+		log.Printf("Failed to fetch PR with release note for %s: %s", repo, err)
+		return "", err
+	}
+
+	if useHierarchy {
+		return hierarchy.Content(issuesResult), nil
+	}
+
+	renderer, err := RendererFor(format, template)
+	if err != nil {
+		return "", err
+	}
+	rendered, err := renderer.Render(buildChangelogModel(repo, issuesResult))
+	if err != nil {
+		return "", err
+	}
+	return string(rendered), nil
+}
+
+// buildChangelogModel converts a raw GitHub issues search result into the
+// structured model Renderers consume.
+func buildChangelogModel(repo string, issuesResult *github.IssuesSearchResult) *ChangelogModel {
+	model := &ChangelogModel{
+		Repo:            repo,
+		PreviousRelease: previousRelease,
+		CurrentRelease:  currentRelease,
+	}
+	for _, issue := range issuesResult.Issues {
+		var labels []string
+		for _, l := range issue.Labels {
+			if l.Name != nil {
+				labels = append(labels, *l.Name)
+			}
+		}
+		model.PRs = append(model.PRs, PRModel{
+			Number:      issue.GetNumber(),
+			Title:       issue.GetTitle(),
+			URL:         issue.GetHTMLURL(),
+			Author:      issue.GetUser().GetLogin(),
+			Labels:      labels,
+			MergedAt:    issue.GetClosedAt(),
+			BodyExcerpt: bodyExcerpt(issue.GetBody()),
+			Type:        fetchLabel(issue.Labels),
+		})
+	}
+	return model
+}
+
+// generateFromGithub delegates to GitHub's generate-notes endpoint instead of
+// running our own search/grouping.
+func generateFromGithub(repo string) (string, error) {
+	if currentRelease == "" {
+		var err error
+		if currentRelease, err = gh.GetLatestRelease(repo); err != nil {
+			log.Printf("Failed to get latest release version when current_release is missing: %s", err)
+			return "", err
+		}
+		log.Printf("Last release version: %s", currentRelease)
+	}
+
+	return gh.GenerateReleaseNotes(repo, currentRelease, previousRelease, currentRelease)
+}
+
+// generateFromGit builds release notes from the local git history at
+// --git-dir, for repos that don't label PRs with a release-note workflow.
+// PR titles/authors are enriched via GraphQL when a --token was supplied.
+func generateFromGit(repo string) (string, error) {
+	var enrich gitlog.Enricher
+	if token != "" {
+		enrich = gh.EnrichPRs
+	} else {
+		log.Printf("No --token supplied, skipping PR title/author enrichment")
+	}
+
+	return gitlog.Generate(gitDir, repo, previousRelease, currentRelease, enrich)
+}
+
 func init() {
 	rootCmd.PersistentFlags().BoolVarP(&save, "save", "s", false, "Save release notes on Github")
 	rootCmd.PersistentFlags().StringVarP(&org, "user", "u", "knabben", "Github owner or org")
@@ -93,6 +240,15 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&token, "token", "t", "", "Github token file (optional)")
 	rootCmd.PersistentFlags().StringVarP(&previousRelease, "previous_release", "p", "", "Previous release")
 	rootCmd.PersistentFlags().StringVarP(&currentRelease, "current_release", "c", "", "Current release")
+	rootCmd.PersistentFlags().BoolVar(&useHierarchy, "hierarchy", false, "Render a SIG/Area/Kind nested changelog instead of the flat label grouping")
+	rootCmd.PersistentFlags().StringVar(&backend, "backend", backendSearch, "Release notes backend: search or github-generated")
+	rootCmd.PersistentFlags().StringVar(&branch, "branch", "", "Base branch to query (defaults to the repo's default branch)")
+	rootCmd.PersistentFlags().StringArrayVar(&branches, "branches", nil, "Repeatable: generate a combined changelog across these base branches")
+	rootCmd.PersistentFlags().StringVar(&source, "source", sourceSearch, "Changelog source: search (GitHub PR search) or git (local git log)")
+	rootCmd.PersistentFlags().StringVar(&gitDir, "git-dir", ".", "Path to the local git repo, used when --source=git")
+	rootCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", "", "Disk cache directory for Github API responses (default $XDG_CACHE_HOME/chrn)")
+	rootCmd.PersistentFlags().StringVar(&format, "format", "markdown", "Output format: markdown, json, yaml, html or slack")
+	rootCmd.PersistentFlags().StringVar(&template, "template", "", "Path to a Go text/template file, overrides --format")
 }
 
 func main() {
@@ -102,7 +258,19 @@ func main() {
 	}
 }
 
-func createQueryString(repo string) ([]string, error) {
+// defaultCacheDir resolves the disk cache location when --cache-dir isn't
+// set: $XDG_CACHE_HOME/chrn, falling back to the OS user cache directory.
+func defaultCacheDir() string {
+	base := os.Getenv("XDG_CACHE_HOME")
+	if base == "" {
+		if dir, err := os.UserCacheDir(); err == nil {
+			base = dir
+		}
+	}
+	return fmt.Sprintf("%s/chrn", base)
+}
+
+func createQueryString(repo, baseBranch string) ([]string, error) {
 	var queries []string
 
 	startTime, err := getReleaseTime(repo, previousRelease)
@@ -124,12 +292,20 @@ func createQueryString(repo string) ([]string, error) {
 		return nil, err
 	}
 
+	if baseBranch == "" {
+		if baseBranch, err = gh.GetDefaultBranch(repo); err != nil {
+			log.Printf("Failed to auto-detect default branch for %s: %s", repo, err)
+			return nil, err
+		}
+		log.Printf("Auto-detected base branch: %s", baseBranch)
+	}
+
 	queries = addQuery(queries, "repo", org, "/", repo)
 	queries = addQuery(queries, "label", label)
 	queries = addQuery(queries, "is", "merged")
 	queries = addQuery(queries, "type", "pr")
 	queries = addQuery(queries, "merged", startTime, "..", endTime)
-	queries = addQuery(queries, "base", "master")
+	queries = addQuery(queries, "base", baseBranch)
 
 	return queries, nil
 }
@@ -169,29 +345,3 @@ func getReleaseTagCreationTime(repo, tag string) (createTime time.Time, err erro
 	}
 	return createTime, nil
 }
-
-func groupedLabelContent(issuesResult *github.IssuesSearchResult) string {
-	prGrouper := []PR{}
-	existentLabels := make([]string, 3)
-
-	for _, issue := range issuesResult.Issues {
-		prGrouper = append(
-			prGrouper, PR{
-				Title: *issue.Title,
-				Link:  *issue.URL,
-				Type:  fetchLabel(issue.Labels),
-			},
-		)
-	}
-	sort.Sort(ByLabel(prGrouper))
-
-	content := fmt.Sprintf("%s: %s -- %s\n", repo, currentRelease, previousRelease)
-	for _, issue := range prGrouper {
-		if !ContainsString(existentLabels, issue.Type) {
-			content += fmt.Sprintf("\n## %s\n", strings.Title(issue.Type))
-			existentLabels = append(existentLabels, issue.Type)
-		}
-		content += fmt.Sprintf("* %s - %s\n", issue.Title, issue.Link)
-	}
-	return content
-}
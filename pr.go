@@ -0,0 +1,47 @@
+package main
+
+import "github.com/google/go-github/github"
+
+// PR represents a single changelog entry extracted from a merged pull request.
+type PR struct {
+	Number int
+	Title  string
+	Link   string
+	Type   string
+}
+
+// ContainsString reports whether s is present in list.
+func ContainsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchLabel returns the first release-note kind label found on the issue,
+// stripping the "release-note-" prefix, or "other" when none match.
+func fetchLabel(labels []github.Label) string {
+	for _, l := range labels {
+		if l.Name == nil {
+			continue
+		}
+		if len(*l.Name) > len("release-note-") && (*l.Name)[:len("release-note-")] == "release-note-" {
+			return (*l.Name)[len("release-note-"):]
+		}
+	}
+	return "other"
+}
+
+// fetchLabelFromPR is fetchLabel for a *github.PullRequest, whose Labels
+// field is a slice of pointers rather than values.
+func fetchLabelFromPR(labels []*github.Label) string {
+	values := make([]github.Label, 0, len(labels))
+	for _, l := range labels {
+		if l != nil {
+			values = append(values, *l)
+		}
+	}
+	return fetchLabel(values)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmlTemplate "html/template"
+	"path/filepath"
+	"sort"
+	"strings"
+	textTemplate "text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ChangelogModel is the structured, renderer-agnostic representation of a
+// changelog: enough PR metadata that any Renderer can produce its own
+// output shape without re-querying GitHub.
+type ChangelogModel struct {
+	Repo            string    `json:"repo" yaml:"repo"`
+	PreviousRelease string    `json:"previous_release" yaml:"previous_release"`
+	CurrentRelease  string    `json:"current_release" yaml:"current_release"`
+	PRs             []PRModel `json:"prs" yaml:"prs"`
+}
+
+// PRModel is one pull request's changelog-relevant metadata.
+type PRModel struct {
+	Number      int       `json:"number" yaml:"number"`
+	Title       string    `json:"title" yaml:"title"`
+	URL         string    `json:"url" yaml:"url"`
+	Author      string    `json:"author" yaml:"author"`
+	Labels      []string  `json:"labels" yaml:"labels"`
+	MergedAt    time.Time `json:"merged_at" yaml:"merged_at"`
+	BodyExcerpt string    `json:"body_excerpt" yaml:"body_excerpt"`
+	Type        string    `json:"type" yaml:"type"`
+	// Branches is set when the PR was collected across multiple base
+	// branches (see collectAcrossBranches), listing every branch it landed
+	// on. Empty for single-branch changelogs.
+	Branches []string `json:"branches,omitempty" yaml:"branches,omitempty"`
+}
+
+// Renderer turns a ChangelogModel into its final output representation.
+type Renderer interface {
+	Render(model *ChangelogModel) ([]byte, error)
+}
+
+// RendererFor resolves the Renderer for --format, or a TemplateRenderer when
+// templatePath is set (which takes precedence over format).
+func RendererFor(format, templatePath string) (Renderer, error) {
+	if templatePath != "" {
+		return NewTemplateRenderer(templatePath)
+	}
+
+	switch format {
+	case "", "markdown":
+		return MarkdownRenderer{}, nil
+	case "json":
+		return JSONRenderer{}, nil
+	case "yaml":
+		return YAMLRenderer{}, nil
+	case "html":
+		return HTMLRenderer{}, nil
+	case "slack":
+		return SlackBlocksRenderer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown --format %q", format)
+	}
+}
+
+// bodyExcerpt truncates a PR body to a short single-line preview.
+func bodyExcerpt(body string) string {
+	body = strings.TrimSpace(strings.SplitN(body, "\n", 2)[0])
+	const maxLen = 120
+	if len(body) > maxLen {
+		return body[:maxLen] + "..."
+	}
+	return body
+}
+
+// MarkdownRenderer renders the changelog as the flat, Type-grouped Markdown
+// this tool has always produced.
+type MarkdownRenderer struct{}
+
+func (MarkdownRenderer) Render(model *ChangelogModel) ([]byte, error) {
+	prs := append([]PRModel(nil), model.PRs...)
+	sort.SliceStable(prs, func(i, j int) bool { return prs[i].Type < prs[j].Type })
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s: %s -- %s\n", model.Repo, model.CurrentRelease, model.PreviousRelease))
+
+	var existentLabels []string
+	for _, pr := range prs {
+		if !ContainsString(existentLabels, pr.Type) {
+			b.WriteString(fmt.Sprintf("\n## %s\n", strings.Title(pr.Type)))
+			existentLabels = append(existentLabels, pr.Type)
+		}
+		b.WriteString(fmt.Sprintf("* %s - %s\n", pr.Title, pr.URL))
+	}
+	return []byte(b.String()), nil
+}
+
+// JSONRenderer renders the changelog model as indented JSON, for consumers
+// that want to post it to a webhook.
+type JSONRenderer struct{}
+
+func (JSONRenderer) Render(model *ChangelogModel) ([]byte, error) {
+	return json.MarshalIndent(model, "", "  ")
+}
+
+// YAMLRenderer renders the changelog model as YAML.
+type YAMLRenderer struct{}
+
+func (YAMLRenderer) Render(model *ChangelogModel) ([]byte, error) {
+	return yaml.Marshal(model)
+}
+
+// HTMLRenderer renders the changelog as a standalone HTML fragment, suitable
+// for embedding in a release email.
+type HTMLRenderer struct{}
+
+const htmlChangelogTemplate = `<h1>{{.Repo}}: {{.CurrentRelease}}</h1>
+<p>Changes since {{.PreviousRelease}}:</p>
+<ul>
+{{- range .PRs}}
+  <li><a href="{{.URL}}">{{.Title}}</a>{{if .Author}} by {{.Author}}{{end}}</li>
+{{- end}}
+</ul>
+`
+
+func (HTMLRenderer) Render(model *ChangelogModel) ([]byte, error) {
+	tmpl, err := htmlTemplate.New("changelog").Parse(htmlChangelogTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, model); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SlackBlocksRenderer renders the changelog as Slack Block Kit JSON, ready to
+// post to a channel via chat.postMessage.
+type SlackBlocksRenderer struct{}
+
+func (SlackBlocksRenderer) Render(model *ChangelogModel) ([]byte, error) {
+	blocks := []map[string]interface{}{
+		{
+			"type": "header",
+			"text": map[string]string{
+				"type": "plain_text",
+				"text": fmt.Sprintf("%s: %s", model.Repo, model.CurrentRelease),
+			},
+		},
+	}
+	for _, pr := range model.PRs {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": map[string]string{
+				"type": "mrkdwn",
+				"text": fmt.Sprintf("<%s|%s>", pr.URL, pr.Title),
+			},
+		})
+	}
+	return json.MarshalIndent(map[string]interface{}{"blocks": blocks}, "", "  ")
+}
+
+// TemplateRenderer renders the changelog through a user-supplied Go
+// text/template file, for layouts this tool doesn't ship a renderer for.
+type TemplateRenderer struct {
+	tmpl *textTemplate.Template
+}
+
+func NewTemplateRenderer(path string) (*TemplateRenderer, error) {
+	tmpl, err := textTemplate.New(filepath.Base(path)).ParseFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("parsing --template %s: %w", path, err)
+	}
+	return &TemplateRenderer{tmpl: tmpl}, nil
+}
+
+func (r *TemplateRenderer) Render(model *ChangelogModel) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := r.tmpl.ExecuteTemplate(&buf, r.tmpl.Name(), model); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}